@@ -0,0 +1,218 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"errors"
+	"sync"
+)
+
+// CancelFunc stops a watch started by WatchableProvider.Watch. It is safe
+// to call more than once.
+type CancelFunc func()
+
+// WatchableProvider is a Provider that can additionally notify callers when
+// a key's value changes, instead of requiring callers to poll Get.
+// Zookeeper, etcd and Consul-backed providers implement this; the static
+// YAML and environment providers do not.
+type WatchableProvider interface {
+	Provider
+
+	// Watch subscribes to updates for key. The returned channel receives a
+	// Value every time the underlying data changes, and is closed after
+	// cancel is called or when the provider is closed. Callers must always
+	// call cancel once they stop ranging over it.
+	Watch(key string) (<-chan Value, CancelFunc)
+}
+
+// DynamicProviderConfig is the bootstrap configuration for a dynamic
+// provider, read from a static provider (YAML/env) before the dynamic
+// provider itself comes online.
+type DynamicProviderConfig struct {
+	// Endpoints are the dynamic store's addresses, e.g. "host:2181" for
+	// Zookeeper or "https://host:2379" for etcd.
+	Endpoints []string `yaml:"endpoints"`
+
+	// Root is prefixed onto every key before it's looked up in the store,
+	// e.g. "/myservice/config".
+	Root string `yaml:"root"`
+
+	// Username/Password authenticate against the store, if it requires it.
+	// Only the etcd provider passes them on; the Consul and Zookeeper
+	// providers connect without credentials.
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	// TLS points at certificate material for connecting to the store over
+	// TLS. None of the built-in etcd, Consul or Zookeeper providers read it;
+	// they connect without TLS.
+	TLS *DynamicProviderTLSConfig `yaml:"tls"`
+}
+
+// DynamicProviderTLSConfig points at the certificate material used to
+// connect to a dynamic store over TLS.
+type DynamicProviderTLSConfig struct {
+	CertFile   string `yaml:"certFile"`
+	KeyFile    string `yaml:"keyFile"`
+	CAFile     string `yaml:"caFile"`
+	ServerName string `yaml:"serverName"`
+}
+
+// dynamicProviderFunc constructs a WatchableProvider from its bootstrap
+// config. Each backend (etcd, Consul, Zookeeper) registers one of these
+// under its own name via RegisterDynamicProviders.
+type dynamicProviderFunc func(DynamicProviderConfig) (WatchableProvider, error)
+
+var (
+	_dynamicProvidersMu sync.Mutex
+	_dynamicProviders   = map[string]dynamicProviderFunc{}
+)
+
+// RegisterDynamicProviders makes a dynamic provider backend available under
+// name, e.g. "etcd", "consul" or "zookeeper", for use in the
+// `config.dynamic.provider` bootstrap key.
+func RegisterDynamicProviders(name string, fn func(DynamicProviderConfig) (WatchableProvider, error)) {
+	_dynamicProvidersMu.Lock()
+	defer _dynamicProvidersMu.Unlock()
+	_dynamicProviders[name] = fn
+}
+
+// NewDynamicProvider bootstraps the dynamic provider named by the
+// `config.dynamic.provider` key in static, using the `config.dynamic.<name>`
+// section as its DynamicProviderConfig.
+func NewDynamicProvider(static Provider) (WatchableProvider, error) {
+	name := static.Get("config.dynamic.provider").AsString()
+	if name == "" {
+		return nil, errNoDynamicProvider
+	}
+
+	_dynamicProvidersMu.Lock()
+	fn, ok := _dynamicProviders[name]
+	_dynamicProvidersMu.Unlock()
+	if !ok {
+		return nil, &unknownDynamicProviderError{name: name}
+	}
+
+	var cfg DynamicProviderConfig
+	static.Get("config.dynamic." + name).PopulateStruct(&cfg)
+	return fn(cfg)
+}
+
+func init() {
+	RegisterDynamicProviders("etcd", newEtcdProvider)
+	RegisterDynamicProviders("consul", newConsulProvider)
+	RegisterDynamicProviders("zookeeper", newZookeeperProvider)
+}
+
+// watchSubscriber pairs a subscriber's channel with the sync.Once guarding
+// its close, shared between subscribe's cancel func and shutdown so the
+// channel is never closed twice.
+type watchSubscriber struct {
+	ch   chan Value
+	once *sync.Once
+}
+
+// watchRegistry is embedded by each concrete dynamic provider to implement
+// the subscriber bookkeeping behind Watch: fan-out to any number of
+// subscribers per key, and cleanup when the service shuts down.
+type watchRegistry struct {
+	mu          sync.Mutex
+	subscribers map[string][]*watchSubscriber
+	closed      bool
+}
+
+func newWatchRegistry() *watchRegistry {
+	return &watchRegistry{subscribers: make(map[string][]*watchSubscriber)}
+}
+
+func (r *watchRegistry) subscribe(key string) (<-chan Value, CancelFunc) {
+	sub := &watchSubscriber{ch: make(chan Value, 1), once: &sync.Once{}}
+
+	r.mu.Lock()
+	r.subscribers[key] = append(r.subscribers[key], sub)
+	r.mu.Unlock()
+
+	cancel := func() {
+		// Lock before entering once, in the same order shutdown does, so a
+		// cancel racing shutdown cannot deadlock against it.
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		sub.once.Do(func() {
+			subs := r.subscribers[key]
+			for i, s := range subs {
+				if s == sub {
+					r.subscribers[key] = append(subs[:i], subs[i+1:]...)
+					break
+				}
+			}
+			close(sub.ch)
+		})
+	}
+	return sub.ch, cancel
+}
+
+// publish delivers value to every live subscriber of key. Slow or closed
+// subscribers never block or panic the caller: sends are best-effort.
+func (r *watchRegistry) publish(key string, value Value) {
+	// Sends never block, so the lock is held across them; this keeps cancel
+	// and shutdown from closing a channel mid-send.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, sub := range r.subscribers[key] {
+		select {
+		case sub.ch <- value:
+		default:
+		}
+	}
+}
+
+// shutdown closes every outstanding subscriber channel. Providers call this
+// from their Close method so callers ranging over a Watch channel observe
+// it closing on service shutdown instead of blocking forever. It shares
+// each subscriber's sync.Once with cancel, so a cancel called after (or
+// during) shutdown is a safe no-op instead of a double close.
+func (r *watchRegistry) shutdown() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	r.closed = true
+	for _, subs := range r.subscribers {
+		for _, sub := range subs {
+			sub.once.Do(func() {
+				close(sub.ch)
+			})
+		}
+	}
+	r.subscribers = nil
+}
+
+type unknownDynamicProviderError struct {
+	name string
+}
+
+func (e *unknownDynamicProviderError) Error() string {
+	return "config: no dynamic provider registered as " + e.name
+}
+
+var errNoDynamicProvider = errors.New("config: config.dynamic.provider is not set")
@@ -0,0 +1,119 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"strings"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+)
+
+// consulProvider is a WatchableProvider backed by Consul's KV store, using
+// blocking queries to detect changes.
+type consulProvider struct {
+	*watchRegistry
+
+	kv     *consul.KV
+	root   string
+	stopCh chan struct{}
+}
+
+func newConsulProvider(cfg DynamicProviderConfig) (WatchableProvider, error) {
+	clientCfg := consul.DefaultConfig()
+	if len(cfg.Endpoints) > 0 {
+		clientCfg.Address = cfg.Endpoints[0]
+	}
+
+	client, err := consul.NewClient(clientCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &consulProvider{
+		watchRegistry: newWatchRegistry(),
+		kv:            client.KV(),
+		root:          cfg.Root,
+		stopCh:        make(chan struct{}),
+	}
+	go p.watchAll()
+	return p, nil
+}
+
+func (p *consulProvider) Name() string {
+	return "consul"
+}
+
+func (p *consulProvider) consulKey(key string) string {
+	return strings.TrimRight(p.root, "/") + "/" + strings.Replace(key, ".", "/", -1)
+}
+
+func (p *consulProvider) Get(key string) Value {
+	pair, _, err := p.kv.Get(p.consulKey(key), nil)
+	if err != nil || pair == nil {
+		return NewValue(p, key, nil, false)
+	}
+	return NewValue(p, key, string(pair.Value), true)
+}
+
+func (p *consulProvider) Watch(key string) (<-chan Value, CancelFunc) {
+	return p.subscribe(key)
+}
+
+// watchAll runs a blocking query against the whole root prefix, republishing
+// any key whose ModifyIndex advances.
+func (p *consulProvider) watchAll() {
+	prefix := strings.TrimRight(p.root, "/") + "/"
+	var lastIndex uint64
+	seen := make(map[string]uint64)
+
+	for {
+		select {
+		case <-p.stopCh:
+			return
+		default:
+		}
+
+		pairs, meta, err := p.kv.List(prefix, &consul.QueryOptions{WaitIndex: lastIndex})
+		if err != nil {
+			time.Sleep(time.Second)
+			continue
+		}
+		lastIndex = meta.LastIndex
+
+		for _, pair := range pairs {
+			if seen[pair.Key] == pair.ModifyIndex {
+				continue
+			}
+			seen[pair.Key] = pair.ModifyIndex
+			key := strings.Replace(strings.TrimPrefix(pair.Key, prefix), "/", ".", -1)
+			p.publish(key, NewValue(p, key, string(pair.Value), true))
+		}
+	}
+}
+
+// Close stops watching and wakes up any goroutine ranging over a channel
+// returned by Watch.
+func (p *consulProvider) Close() error {
+	close(p.stopCh)
+	p.shutdown()
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/clientv3"
+)
+
+// etcdProvider is a WatchableProvider backed by etcd v3's KV and Watch
+// APIs.
+type etcdProvider struct {
+	*watchRegistry
+
+	client      *clientv3.Client
+	root        string
+	watchCtx    context.Context
+	cancelWatch context.CancelFunc
+}
+
+func newEtcdProvider(cfg DynamicProviderConfig) (WatchableProvider, error) {
+	clientCfg := clientv3.Config{
+		Endpoints:   cfg.Endpoints,
+		DialTimeout: 5 * time.Second,
+		Username:    cfg.Username,
+		Password:    cfg.Password,
+	}
+
+	cli, err := clientv3.New(clientCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	p := &etcdProvider{
+		watchRegistry: newWatchRegistry(),
+		client:        cli,
+		root:          cfg.Root,
+		watchCtx:      watchCtx,
+		cancelWatch:   cancel,
+	}
+	go p.watchAll()
+	return p, nil
+}
+
+func (p *etcdProvider) Name() string {
+	return "etcd"
+}
+
+func (p *etcdProvider) etcdKey(key string) string {
+	return strings.TrimRight(p.root, "/") + "/" + strings.Replace(key, ".", "/", -1)
+}
+
+func (p *etcdProvider) Get(key string) Value {
+	resp, err := p.client.Get(context.Background(), p.etcdKey(key))
+	if err != nil || len(resp.Kvs) == 0 {
+		return NewValue(p, key, nil, false)
+	}
+	return NewValue(p, key, string(resp.Kvs[0].Value), true)
+}
+
+func (p *etcdProvider) Watch(key string) (<-chan Value, CancelFunc) {
+	return p.subscribe(key)
+}
+
+// watchAll listens for every change under root and republishes it to any
+// subscriber whose dotted key matches, for the lifetime of the provider.
+func (p *etcdProvider) watchAll() {
+	prefix := strings.TrimRight(p.root, "/") + "/"
+	watchCh := p.client.Watch(p.watchCtx, prefix, clientv3.WithPrefix())
+	for resp := range watchCh {
+		for _, ev := range resp.Events {
+			key := strings.Replace(strings.TrimPrefix(string(ev.Kv.Key), prefix), "/", ".", -1)
+			p.publish(key, NewValue(p, key, string(ev.Kv.Value), true))
+		}
+	}
+}
+
+// Close stops the watch goroutine and releases the underlying client, and
+// wakes up any goroutine ranging over a channel returned by Watch.
+func (p *etcdProvider) Close() error {
+	p.cancelWatch()
+	p.shutdown()
+	return p.client.Close()
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDynamicProvider is a minimal in-memory WatchableProvider, standing in
+// for etcd/Consul/Zookeeper in tests that only care about priority and
+// change notification, not any particular wire protocol.
+type fakeDynamicProvider struct {
+	*watchRegistry
+
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func newFakeDynamicProvider() *fakeDynamicProvider {
+	return &fakeDynamicProvider{
+		watchRegistry: newWatchRegistry(),
+		values:        make(map[string]string),
+	}
+}
+
+func (p *fakeDynamicProvider) Name() string { return "fake-dynamic" }
+
+func (p *fakeDynamicProvider) Get(key string) Value {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	v, ok := p.values[key]
+	return NewValue(p, key, v, ok)
+}
+
+func (p *fakeDynamicProvider) Watch(key string) (<-chan Value, CancelFunc) {
+	return p.subscribe(key)
+}
+
+func (p *fakeDynamicProvider) Set(key, value string) {
+	p.mu.Lock()
+	p.values[key] = value
+	p.mu.Unlock()
+	p.publish(key, NewValue(p, key, value, true))
+}
+
+func TestMergeProvider_PriorityMatrix(t *testing.T) {
+	yamlProvider := NewYAMLProviderFromBytes([]byte("stuff:\n  greeting: from-yaml\n  port: 1\n"))
+
+	os.Setenv("CONFIG__stuff__greeting", "from-env")
+	defer os.Unsetenv("CONFIG__stuff__greeting")
+	envProvider := NewEnvironmentProvider()
+
+	dyn := newFakeDynamicProvider()
+	dyn.Set("stuff.greeting", "from-dynamic")
+
+	tests := []struct {
+		name     string
+		stack    []Provider
+		key      string
+		expected string
+	}{
+		{"yaml alone", []Provider{yamlProvider}, "stuff.greeting", "from-yaml"},
+		{"env overrides yaml", []Provider{yamlProvider, envProvider}, "stuff.greeting", "from-env"},
+		{"dynamic overrides env and yaml", []Provider{yamlProvider, envProvider, dyn}, "stuff.greeting", "from-dynamic"},
+		{"yaml value untouched by dynamic", []Provider{yamlProvider, envProvider, dyn}, "stuff.port", "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := NewProviderGroup(tt.stack...)
+			assert.Equal(t, tt.expected, merged.Get(tt.key).AsString())
+		})
+	}
+}
+
+func TestMergeProvider_WatchNotifiesOnDynamicChange(t *testing.T) {
+	yamlProvider := NewYAMLProviderFromBytes([]byte("stuff:\n  greeting: from-yaml\n"))
+	dyn := newFakeDynamicProvider()
+	merged := NewProviderGroup(yamlProvider, dyn)
+
+	ch, cancel := merged.(*mergeProvider).Watch("stuff.greeting")
+	defer cancel()
+
+	dyn.Set("stuff.greeting", "updated")
+
+	select {
+	case v := <-ch:
+		assert.Equal(t, "updated", v.AsString())
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for watch notification")
+	}
+}
+
+func TestWatchRegistry_ShutdownClosesSubscribers(t *testing.T) {
+	dyn := newFakeDynamicProvider()
+	ch, cancel := dyn.Watch("stuff.greeting")
+	defer cancel()
+
+	dyn.shutdown()
+
+	select {
+	case _, ok := <-ch:
+		assert.False(t, ok, "channel should be closed on shutdown")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for shutdown to close the channel")
+	}
+}
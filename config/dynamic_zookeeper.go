@@ -0,0 +1,112 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// zookeeperProvider is a WatchableProvider backed by Zookeeper znodes,
+// using zk's native one-shot watches, automatically re-armed after firing.
+type zookeeperProvider struct {
+	*watchRegistry
+
+	conn   *zk.Conn
+	root   string
+	stopCh chan struct{}
+}
+
+func newZookeeperProvider(cfg DynamicProviderConfig) (WatchableProvider, error) {
+	conn, _, err := zk.Connect(cfg.Endpoints, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &zookeeperProvider{
+		watchRegistry: newWatchRegistry(),
+		conn:          conn,
+		root:          cfg.Root,
+		stopCh:        make(chan struct{}),
+	}
+	return p, nil
+}
+
+func (p *zookeeperProvider) Name() string {
+	return "zookeeper"
+}
+
+func (p *zookeeperProvider) znode(key string) string {
+	return strings.TrimRight(p.root, "/") + "/" + strings.Replace(key, ".", "/", -1)
+}
+
+func (p *zookeeperProvider) Get(key string) Value {
+	data, _, err := p.conn.Get(p.znode(key))
+	if err != nil {
+		return NewValue(p, key, nil, false)
+	}
+	return NewValue(p, key, string(data), true)
+}
+
+// Watch arms a native Zookeeper watch on key's znode. Each time it fires,
+// the new value is published and the watch is re-armed, until cancel is
+// called or the provider is closed.
+func (p *zookeeperProvider) Watch(key string) (<-chan Value, CancelFunc) {
+	ch, cancel := p.subscribe(key)
+	done := make(chan struct{})
+	var once sync.Once
+	go p.watchLoop(key, done)
+	return ch, func() {
+		once.Do(func() { close(done) })
+		cancel()
+	}
+}
+
+func (p *zookeeperProvider) watchLoop(key string, done <-chan struct{}) {
+	for {
+		data, _, eventCh, err := p.conn.GetW(p.znode(key))
+		if err != nil {
+			return
+		}
+		p.publish(key, NewValue(p, key, string(data), true))
+
+		select {
+		case <-eventCh:
+			// znode changed; loop around to re-fetch and re-arm.
+		case <-done:
+			return
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+// Close tears down the Zookeeper connection and wakes up any goroutine
+// ranging over a channel returned by Watch.
+func (p *zookeeperProvider) Close() error {
+	close(p.stopCh)
+	p.shutdown()
+	p.conn.Close()
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// _envPrefix is prepended to the dotted key, with "." replaced by "__", to
+// form the environment variable name: foo.bar.baz -> CONFIG__foo__bar__baz.
+const _envPrefix = "CONFIG"
+
+// environmentProvider is a static Provider backed by CONFIG__-prefixed
+// environment variables. It is always the highest-priority static provider,
+// letting deploy tooling override any YAML value without a code change.
+type environmentProvider struct{}
+
+// NewEnvironmentProvider returns a Provider backed by the process's
+// environment variables.
+func NewEnvironmentProvider() Provider {
+	return environmentProvider{}
+}
+
+func (environmentProvider) Name() string {
+	return "environment"
+}
+
+func (environmentProvider) Get(key string) Value {
+	if key == Root {
+		return NewValue(environmentProvider{}, key, nil, false)
+	}
+	envKey := _envPrefix + "__" + strings.Replace(key, ".", "__", -1)
+	if v, ok := os.LookupEnv(envKey); ok {
+		return NewValue(environmentProvider{}, key, v, true)
+	}
+	return NewValue(environmentProvider{}, key, nil, false)
+}
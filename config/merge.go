@@ -0,0 +1,92 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import "sync"
+
+// mergeProvider stacks providers in increasing priority order: a Get that
+// finds a value in a later provider wins over one found in an earlier one.
+// This is how UberFx layers static YAML (lowest), environment variables,
+// and dynamic providers (highest).
+type mergeProvider struct {
+	providers []Provider
+}
+
+// NewProviderGroup combines providers into a single Provider, with later
+// entries taking precedence over earlier ones.
+func NewProviderGroup(providers ...Provider) Provider {
+	return &mergeProvider{providers: providers}
+}
+
+func (m *mergeProvider) Name() string {
+	return "merge"
+}
+
+func (m *mergeProvider) Get(key string) Value {
+	var best Value
+	for _, p := range m.providers {
+		if v := p.Get(key); v.HasValue() {
+			best = v
+		}
+	}
+	return best
+}
+
+// Watch subscribes to every watchable provider in the stack and, whenever
+// any of them report a change for key, re-evaluates the merged Get(key) so
+// subscribers always see the correctly-prioritized value, not just the
+// value from whichever provider happened to change.
+func (m *mergeProvider) Watch(key string) (<-chan Value, CancelFunc) {
+	out := make(chan Value, 1)
+	var cancels []CancelFunc
+	var wg sync.WaitGroup
+
+	for _, p := range m.providers {
+		wp, ok := p.(WatchableProvider)
+		if !ok {
+			continue
+		}
+		ch, cancel := wp.Watch(key)
+		cancels = append(cancels, cancel)
+		wg.Add(1)
+		go func(ch <-chan Value) {
+			defer wg.Done()
+			for range ch {
+				select {
+				case out <- m.Get(key):
+				default:
+				}
+			}
+		}(ch)
+	}
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			for _, c := range cancels {
+				c()
+			}
+			wg.Wait()
+			close(out)
+		})
+	}
+	return out, cancel
+}
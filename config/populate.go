@@ -0,0 +1,123 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// populateStruct re-marshals value (already-decoded YAML-ish data: maps,
+// slices, scalars) and unmarshals it into target via yaml.Unmarshal, so a
+// single decode path is shared by every provider regardless of the
+// underlying format. Afterwards, any field left at its zero value that
+// carries a `default:"..."` struct tag (see the package doc's canonical
+// example) is filled in from that tag.
+func populateStruct(value interface{}, target interface{}) bool {
+	raw, err := yaml.Marshal(value)
+	if err != nil {
+		return false
+	}
+	if err := yaml.Unmarshal(raw, target); err != nil {
+		return false
+	}
+	applyDefaults(reflect.ValueOf(target))
+	return true
+}
+
+// applyDefaults walks v (expected to be a pointer to a struct) and, for
+// every zero-valued field tagged `default:"..."`, parses the tag and sets
+// the field to it. Nested structs are visited recursively so a
+// `default:"..."` tag works the same whether the struct is top-level or
+// embedded in another config struct.
+func applyDefaults(v reflect.Value) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+		if !fv.CanSet() {
+			continue
+		}
+
+		if fv.Kind() == reflect.Struct {
+			applyDefaults(fv.Addr())
+			continue
+		}
+		if fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct {
+			applyDefaults(fv)
+			continue
+		}
+
+		def, ok := field.Tag.Lookup("default")
+		if !ok || !fv.IsZero() {
+			continue
+		}
+		setDefault(fv, def)
+	}
+}
+
+// setDefault parses raw according to fv's type and assigns it. Unparseable
+// defaults are left as the zero value rather than causing populateStruct to
+// fail outright.
+func setDefault(fv reflect.Value, raw string) {
+	// time.Duration is an int64 under the hood, so it must be checked
+	// before the generic Int case below.
+	if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+		if d, err := time.ParseDuration(raw); err == nil {
+			fv.SetInt(int64(d))
+		}
+		return
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(raw); err == nil {
+			fv.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			fv.SetInt(n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseUint(raw, 10, 64); err == nil {
+			fv.SetUint(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(raw, 64); err == nil {
+			fv.SetFloat(f)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type populateSubConfig struct {
+	MaxAttempts int           `yaml:"maxAttempts" default:"1"`
+	BaseBackoff time.Duration `yaml:"baseBackoff" default:"50ms"`
+}
+
+type populateParentConfig struct {
+	Retry *populateSubConfig `yaml:"retry"`
+}
+
+func TestApplyDefaults_ThroughPointerField(t *testing.T) {
+	var cfg populateParentConfig
+	ok := populateStruct(map[string]interface{}{
+		"retry": map[string]interface{}{"maxAttempts": 5},
+	}, &cfg)
+
+	assert.True(t, ok)
+	if assert.NotNil(t, cfg.Retry) {
+		assert.Equal(t, 5, cfg.Retry.MaxAttempts)
+		assert.Equal(t, 50*time.Millisecond, cfg.Retry.BaseBackoff)
+	}
+}
+
+func TestApplyDefaults_NilPointerFieldLeftUnset(t *testing.T) {
+	var cfg populateParentConfig
+	ok := populateStruct(map[string]interface{}{}, &cfg)
+
+	assert.True(t, ok)
+	assert.Nil(t, cfg.Retry)
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import "fmt"
+
+// Root is the key that refers to the entire configuration tree.
+const Root = ""
+
+// Provider is the interface for anything that can supply configuration
+// values by key. See the package doc for the priority rules UberFx applies
+// when several providers are stacked together.
+type Provider interface {
+	// Name identifies the provider in logs and error messages, e.g. "yaml"
+	// or "environment".
+	Name() string
+
+	// Get returns the Value for key, or a Value with HasValue() == false
+	// if the provider has nothing for that key.
+	Get(key string) Value
+}
+
+// Value is the result of looking a key up in a Provider.
+type Value struct {
+	provider Provider
+	key      string
+	value    interface{}
+	found    bool
+}
+
+// NewValue constructs a Value for key on provider. found reports whether the
+// provider actually had data for key.
+func NewValue(provider Provider, key string, value interface{}, found bool) Value {
+	return Value{provider: provider, key: key, value: value, found: found}
+}
+
+// HasValue reports whether the key was found by the provider.
+func (v Value) HasValue() bool {
+	return v.found
+}
+
+// AsString returns the value coerced to a string, or "" if it wasn't found.
+func (v Value) AsString() string {
+	s, _ := v.TryAsString()
+	return s
+}
+
+// TryAsString returns the value coerced to a string and whether it was
+// found at all.
+func (v Value) TryAsString() (string, bool) {
+	if !v.found {
+		return "", false
+	}
+	if v.value == nil {
+		return "", true
+	}
+	if s, ok := v.value.(string); ok {
+		return s, true
+	}
+	return fmt.Sprint(v.value), true
+}
+
+// PopulateStruct fills target, a pointer to a struct, from this Value's
+// underlying data. It returns true if target was populated.
+func (v Value) PopulateStruct(target interface{}) bool {
+	if !v.found {
+		return false
+	}
+	return populateStruct(v.value, target)
+}
+
+// OnChange registers fn to be called with the new Value whenever the
+// underlying key changes, for providers that support watching (see
+// WatchableProvider). It is a no-op for static providers. The subscription
+// lives for the lifetime of the provider; it is torn down when the
+// provider's Close method closes the watch, not by OnChange itself.
+func (v Value) OnChange(fn func(Value)) {
+	wp, ok := v.provider.(WatchableProvider)
+	if !ok {
+		return
+	}
+	ch, _ := wp.Watch(v.key)
+	go func() {
+		for updated := range ch {
+			fn(updated)
+		}
+	}()
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// yamlProvider is a static Provider backed by an in-memory YAML document.
+type yamlProvider struct {
+	data map[string]interface{}
+}
+
+// NewYAMLProviderFromBytes parses raw YAML into a static Provider.
+func NewYAMLProviderFromBytes(raw []byte) Provider {
+	var data map[interface{}]interface{}
+	// An empty document is valid and simply yields no keys.
+	_ = yaml.Unmarshal(raw, &data)
+	return &yamlProvider{data: normalizeKeys(data)}
+}
+
+// normalizeKeys recursively converts the map[interface{}]interface{} that
+// yaml.v2 produces for nested mappings into map[string]interface{}, so
+// dotted-key lookups and PopulateStruct don't have to special-case it.
+func normalizeKeys(v interface{}) map[string]interface{} {
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, val := range m {
+		key, ok := k.(string)
+		if !ok {
+			continue
+		}
+		if nested, ok := val.(map[interface{}]interface{}); ok {
+			out[key] = normalizeKeys(nested)
+		} else {
+			out[key] = val
+		}
+	}
+	return out
+}
+
+func (p *yamlProvider) Name() string {
+	return "yaml"
+}
+
+func (p *yamlProvider) Get(key string) Value {
+	if key == Root {
+		return NewValue(p, key, p.data, p.data != nil)
+	}
+
+	var node interface{} = p.data
+	for _, part := range strings.Split(key, ".") {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return NewValue(p, key, nil, false)
+		}
+		node, ok = m[part]
+		if !ok {
+			return NewValue(p, key, nil, false)
+		}
+	}
+	return NewValue(p, key, node, true)
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rpc
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// Filter decorates a transport.UnaryHandler, the inbound counterpart to the
+// client package's outbound Filter. Implementations run before and/or after
+// delegating to next, and may short-circuit by returning without calling it.
+type Filter interface {
+	Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, next transport.UnaryHandler) error
+}
+
+// FilterFunc adapts an ordinary function to a Filter.
+type FilterFunc func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, next transport.UnaryHandler) error
+
+// Handle calls f(ctx, req, resw, next).
+func (f FilterFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, next transport.UnaryHandler) error {
+	return f(ctx, req, resw, next)
+}
+
+var (
+	_globalFiltersMu sync.Mutex
+	_globalFilters   []Filter
+)
+
+// RegisterFilter installs a Filter that every procedure registered after
+// this call will run, in registration order, before any filters passed via
+// WithFilters. It is typically called during module Setup, before any
+// handler has been registered.
+func RegisterFilter(filter Filter) {
+	_globalFiltersMu.Lock()
+	defer _globalFiltersMu.Unlock()
+	_globalFilters = append(_globalFilters, filter)
+}
+
+func globalFilters() []Filter {
+	_globalFiltersMu.Lock()
+	defer _globalFiltersMu.Unlock()
+	out := make([]Filter, len(_globalFilters))
+	copy(out, _globalFilters)
+	return out
+}
+
+// filterChain threads an inbound request through a sequence of Filters and
+// on to the handler that implements the procedure. It is stateless per call
+// (the filter position is passed as a parameter, not stored), so a single
+// *filterChain is safe to share across concurrent requests.
+type filterChain struct {
+	filters []Filter
+	handler transport.UnaryHandler
+}
+
+// newFilterChain builds a transport.UnaryHandler that runs filters in order
+// before delegating to handler.
+func newFilterChain(filters []Filter, handler transport.UnaryHandler) transport.UnaryHandler {
+	return &filterChain{filters: filters, handler: handler}
+}
+
+// Handle implements transport.UnaryHandler.
+func (fc *filterChain) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return fc.handleAt(0, ctx, req, resw)
+}
+
+func (fc *filterChain) handleAt(i int, ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	if i >= len(fc.filters) {
+		return fc.handler.Handle(ctx, req, resw)
+	}
+	next := transport.UnaryHandlerFunc(func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+		return fc.handleAt(i+1, ctx, req, resw)
+	})
+	return fc.filters[i].Handle(ctx, req, resw, next)
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type orderRecordingFilter struct {
+	name  string
+	order *[]string
+}
+
+func (f orderRecordingFilter) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, next transport.UnaryHandler) error {
+	*f.order = append(*f.order, f.name)
+	return next.Handle(ctx, req, resw)
+}
+
+func TestFilterChain_Ordering(t *testing.T) {
+	var order []string
+	chain := newFilterChain(
+		[]Filter{
+			orderRecordingFilter{name: "first", order: &order},
+			orderRecordingFilter{name: "second", order: &order},
+		},
+		makeHandler(nil),
+	)
+
+	err := chain.Handle(context.Background(), makeRequest(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, order)
+}
+
+func TestFilterChain_ErrorShortCircuits(t *testing.T) {
+	var order []string
+	errBoom := errors.New("boom")
+	chain := newFilterChain(
+		[]Filter{
+			orderRecordingFilter{name: "first", order: &order},
+			FilterFunc(func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, next transport.UnaryHandler) error {
+				return errBoom
+			}),
+			orderRecordingFilter{name: "never", order: &order},
+		},
+		makeHandler(nil),
+	)
+
+	err := chain.Handle(context.Background(), makeRequest(), nil)
+	assert.Equal(t, errBoom, err)
+	assert.Equal(t, []string{"first"}, order)
+}
+
+func TestFilterChain_PropagatesContextValue(t *testing.T) {
+	type ctxKey struct{}
+	chain := newFilterChain(
+		[]Filter{
+			FilterFunc(func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, next transport.UnaryHandler) error {
+				return next.Handle(context.WithValue(ctx, ctxKey{}, "propagated"), req, resw)
+			}),
+		},
+		transport.UnaryHandlerFunc(func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+			assert.Equal(t, "propagated", ctx.Value(ctxKey{}))
+			return nil
+		}),
+	)
+
+	err := chain.Handle(context.Background(), makeRequest(), nil)
+	assert.NoError(t, err)
+}
+
+func TestPanicRecoveryFilter(t *testing.T) {
+	chain := newFilterChain(
+		[]Filter{panicRecoveryFilter()},
+		transport.UnaryHandlerFunc(func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+			panic("kaboom")
+		}),
+	)
+
+	err := chain.Handle(context.Background(), makeRequest(), nil)
+	assert.Error(t, err)
+}
+
+func TestRateLimitFilter_RejectsOverLimit(t *testing.T) {
+	chain := newFilterChain(
+		[]Filter{rateLimitFilter(1, time.Minute)},
+		makeHandler(nil),
+	)
+
+	assert.NoError(t, chain.Handle(context.Background(), makeRequest(), nil))
+	assert.Equal(t, errRateLimited, chain.Handle(context.Background(), makeRequest(), nil))
+}
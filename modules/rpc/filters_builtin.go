@@ -0,0 +1,127 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/fx/auth"
+	"go.uber.org/fx/ulog"
+	"go.uber.org/yarpc/api/transport"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// panicRecoveryFilter converts a panic in a handler (or in any filter
+// further down the chain) into an error, so a single bad request can't take
+// down the dispatcher's goroutine.
+func panicRecoveryFilter() Filter {
+	return FilterFunc(func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, next transport.UnaryHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic handling %q: %v", req.Procedure, r)
+			}
+		}()
+		return next.Handle(ctx, req, resw)
+	})
+}
+
+// loggingFilter logs one line per inbound request once the call completes,
+// including its duration and outcome.
+func loggingFilter(log ulog.Log) Filter {
+	return FilterFunc(func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, next transport.UnaryHandler) error {
+		start := time.Now()
+		err := next.Handle(ctx, req, resw)
+		log.Info(
+			"handled inbound request",
+			"procedure", req.Procedure,
+			"caller", req.Caller,
+			"duration", time.Since(start),
+			"error", err,
+		)
+		return err
+	})
+}
+
+// tracingFilter extracts an OpenTracing span from the inbound request's
+// transport headers, if one was propagated by the caller, and attaches it
+// to ctx for the remainder of the chain.
+func tracingFilter(tracer opentracing.Tracer) Filter {
+	return FilterFunc(func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, next transport.UnaryHandler) error {
+		carrier := opentracing.TextMapCarrier(req.Headers.Items())
+		spanCtx, err := tracer.Extract(opentracing.TextMap, carrier)
+		if err != nil {
+			// No (usable) span on the wire; proceed without one rather
+			// than failing the request.
+			return next.Handle(ctx, req, resw)
+		}
+
+		span := tracer.StartSpan(req.Procedure, opentracing.ChildOf(spanCtx))
+		defer span.Finish()
+		return next.Handle(opentracing.ContextWithSpan(ctx, span), req, resw)
+	})
+}
+
+// authFilter verifies the caller-identity baggage on the inbound span
+// against the registered auth client before allowing the request through.
+func authFilter() Filter {
+	return FilterFunc(func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, next transport.UnaryHandler) error {
+		span := opentracing.SpanFromContext(ctx)
+		if span == nil {
+			return next.Handle(ctx, req, resw)
+		}
+		if err := auth.Authenticate(ctx); err != nil {
+			return fmt.Errorf("auth failed for procedure %q: %w", req.Procedure, err)
+		}
+		return next.Handle(ctx, req, resw)
+	})
+}
+
+// errRateLimited is returned when a procedure-scoped rate limiter rejects a
+// request.
+var errRateLimited = fmt.Errorf("rpc: rate limit exceeded")
+
+// rateLimitFilter rejects inbound requests once more than limit have been
+// handled within window, per procedure.
+func rateLimitFilter(limit int, window time.Duration) Filter {
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	windowStart := time.Now()
+
+	return FilterFunc(func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, next transport.UnaryHandler) error {
+		mu.Lock()
+		if time.Since(windowStart) > window {
+			counts = make(map[string]int)
+			windowStart = time.Now()
+		}
+		counts[req.Procedure]++
+		exceeded := counts[req.Procedure] > limit
+		mu.Unlock()
+
+		if exceeded {
+			return errRateLimited
+		}
+		return next.Handle(ctx, req, resw)
+	})
+}
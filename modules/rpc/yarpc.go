@@ -0,0 +1,90 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package rpc wires YARPC dispatchers and unary handlers into a service,
+// wrapping every registered handler with an inbound filter chain.
+package rpc
+
+import (
+	"sync"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+)
+
+// defaultYarpcDispatcher is the dispatcher used when a service hasn't
+// registered one of its own, e.g. in unit tests that only exercise handler
+// registration.
+var defaultYarpcDispatcher = yarpc.NewDispatcher(yarpc.Config{Name: "default"})
+
+var (
+	_dispatcherMu sync.Mutex
+	_dispatcher   = defaultYarpcDispatcher
+)
+
+// RegisterDispatcher sets the active YARPC dispatcher that subsequent
+// handler registrations are attached to.
+func RegisterDispatcher(d *yarpc.Dispatcher) {
+	_dispatcherMu.Lock()
+	defer _dispatcherMu.Unlock()
+	_dispatcher = d
+}
+
+func activeDispatcher() *yarpc.Dispatcher {
+	_dispatcherMu.Lock()
+	defer _dispatcherMu.Unlock()
+	return _dispatcher
+}
+
+// handlerConfig accumulates the per-procedure options passed to a
+// registration call.
+type handlerConfig struct {
+	filters []Filter
+}
+
+// HandlerOption customizes how a single procedure is registered.
+type HandlerOption func(*handlerConfig)
+
+// WithFilters appends filters that run only for this procedure, after the
+// globally registered filters.
+func WithFilters(filters ...Filter) HandlerOption {
+	return func(c *handlerConfig) {
+		c.filters = append(c.filters, filters...)
+	}
+}
+
+// RegisterUnaryHandler registers handler for procedure on the active
+// dispatcher's raw encoding, wrapping it with the global filter chain
+// followed by any per-procedure filters supplied via opts.
+func RegisterUnaryHandler(procedure string, handler transport.UnaryHandler, opts ...HandlerOption) {
+	var cfg handlerConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	wrapped := newFilterChain(append(globalFilters(), cfg.filters...), handler)
+
+	activeDispatcher().Register([]transport.Procedure{
+		{
+			Name:        procedure,
+			HandlerSpec: transport.NewUnaryHandlerSpec(wrapped),
+		},
+	})
+}
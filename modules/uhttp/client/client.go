@@ -0,0 +1,190 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package client provides an outbound HTTP client with a composable chain of
+// filters, similar in spirit to the inbound middleware used by the rpc and
+// uhttp server packages.
+package client
+
+import (
+	"net"
+	"net/http"
+	"sync"
+
+	"go.uber.org/fx"
+	"go.uber.org/fx/auth"
+	"go.uber.org/fx/config"
+	"go.uber.org/fx/ulog"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// _serviceName is the caller identity authenticationFilter attaches as auth
+// baggage, so filters and tests can refer to "who we are" without threading
+// a Host through every call. It is read on every call; nothing in this
+// package fills it from config, so it is empty unless assigned.
+var _serviceName string
+
+// BasicClient is the minimal interface an outbound HTTP transport must
+// satisfy. It mirrors http.Client.Do, but takes an fx.Context so filters can
+// observe deadlines, tracing spans and baggage.
+type BasicClient interface {
+	Do(ctx fx.Context, req *http.Request) (*http.Response, error)
+}
+
+// BasicClientFunc adapts an ordinary function to a BasicClient.
+type BasicClientFunc func(ctx fx.Context, req *http.Request) (*http.Response, error)
+
+// Do calls f(ctx, req).
+func (f BasicClientFunc) Do(ctx fx.Context, req *http.Request) (*http.Response, error) {
+	return f(ctx, req)
+}
+
+// Filter decorates a BasicClient, running before and/or after the call is
+// handed to the next link in the chain.
+type Filter interface {
+	Apply(ctx fx.Context, req *http.Request, next BasicClient) (*http.Response, error)
+}
+
+// FilterFunc adapts an ordinary function to a Filter.
+type FilterFunc func(ctx fx.Context, req *http.Request, next BasicClient) (*http.Response, error)
+
+// Apply calls f(ctx, req, next).
+func (f FilterFunc) Apply(ctx fx.Context, req *http.Request, next BasicClient) (*http.Response, error) {
+	return f(ctx, req, next)
+}
+
+var (
+	_globalFiltersMu sync.Mutex
+	_globalFilters   []Filter
+)
+
+// RegisterFilter installs a Filter that every BasicClient constructed after
+// this call will run, in registration order, before any filters passed
+// explicitly to newExecutionChain. It is typically called from an init()
+// function or module Setup, before any client has been created.
+func RegisterFilter(filter Filter) {
+	_globalFiltersMu.Lock()
+	defer _globalFiltersMu.Unlock()
+	_globalFilters = append(_globalFilters, filter)
+}
+
+func globalFilters() []Filter {
+	_globalFiltersMu.Lock()
+	defer _globalFiltersMu.Unlock()
+	out := make([]Filter, len(_globalFilters))
+	copy(out, _globalFilters)
+	return out
+}
+
+// ExecutionChain threads a request through a sequence of Filters and on to a
+// final BasicClient transport. It is stateless per call (the filter
+// position is passed as a parameter, not stored), so a single
+// *ExecutionChain is safe to share across concurrent requests.
+type ExecutionChain struct {
+	Filters   []Filter
+	Finalizer BasicClient
+}
+
+// newExecutionChain builds an ExecutionChain from the globally registered
+// filters followed by the filters passed in, terminating in finalTransport.
+func newExecutionChain(filters []Filter, finalTransport BasicClient) *ExecutionChain {
+	all := append(globalFilters(), filters...)
+	return &ExecutionChain{
+		Filters:   all,
+		Finalizer: finalTransport,
+	}
+}
+
+// Do executes the chain, starting at the first filter.
+func (ec *ExecutionChain) Do(ctx fx.Context, req *http.Request) (*http.Response, error) {
+	return ec.doAt(0, ctx, req)
+}
+
+func (ec *ExecutionChain) doAt(i int, ctx fx.Context, req *http.Request) (*http.Response, error) {
+	if i >= len(ec.Filters) {
+		return ec.Finalizer.Do(ctx, req)
+	}
+	next := BasicClientFunc(func(ctx fx.Context, req *http.Request) (*http.Response, error) {
+		return ec.doAt(i+1, ctx, req)
+	})
+	return ec.Filters[i].Apply(ctx, req, next)
+}
+
+// tracingFilter starts (or continues) an OpenTracing span for the outbound
+// call and injects it into the request headers.
+func tracingFilter() Filter {
+	return FilterFunc(func(ctx fx.Context, req *http.Request, next BasicClient) (*http.Response, error) {
+		span := opentracing.SpanFromContext(ctx)
+		if span == nil {
+			return next.Do(ctx, req)
+		}
+		child := opentracing.StartSpan(
+			"http_outbound",
+			opentracing.ChildOf(span.Context()),
+		)
+		defer child.Finish()
+
+		carrier := opentracing.HTTPHeadersCarrier(req.Header)
+		if err := child.Tracer().Inject(child.Context(), opentracing.HTTPHeaders, carrier); err != nil {
+			return nil, err
+		}
+		return next.Do(ctx, req)
+	})
+}
+
+// isRetryableNetError is the fallback used by IsRetryable for errors that
+// haven't been run through the error-classification filter, e.g. because a
+// test or a caller constructed its own BasicClient.
+func isRetryableNetError(err error) bool {
+	if netErr, ok := err.(net.Error); ok {
+		return netErr.Timeout() || netErr.Temporary()
+	}
+	return false
+}
+
+// authInfo is the subset of service.Host the authentication filter takes: a
+// config.Provider and a logger to report authentication failures. The
+// filter uses only the logger; the identity it sends comes from
+// _serviceName.
+type authInfo interface {
+	Config() config.Provider
+	Logger() ulog.Log
+}
+
+// authenticationFilter attaches service-to-service authentication baggage
+// (the caller's identity) to every outbound request's tracing span, failing
+// the call if the registered auth client rejects the caller.
+func authenticationFilter(info authInfo) Filter {
+	return FilterFunc(func(ctx fx.Context, req *http.Request, next BasicClient) (*http.Response, error) {
+		span := opentracing.SpanFromContext(ctx)
+		if span == nil {
+			return next.Do(ctx, req)
+		}
+
+		if err := auth.Authenticate(ctx); err != nil {
+			info.Logger().Error("outbound call rejected by auth client", "error", err)
+			return nil, err
+		}
+
+		span.SetBaggageItem(auth.ServiceAuth, _serviceName)
+		return next.Do(ctx, req)
+	})
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"go.uber.org/fx/config"
+	"go.uber.org/fx/ulog"
+
+	"github.com/uber-go/tally"
+)
+
+// FiltersConfig is the shape of the `modules.http.client.filters` YAML
+// block. Each sub-section is optional; a filter is only installed if its
+// section is present.
+type FiltersConfig struct {
+	Retry          *RetryConfig          `yaml:"retry"`
+	CircuitBreaker *CircuitBreakerConfig `yaml:"circuitBreaker"`
+	RateLimit      *RateLimitConfig      `yaml:"rateLimit"`
+}
+
+// FiltersFromConfig builds the built-in filters described by the
+// `modules.http.client.filters` section of provider, in the fixed order
+// rate-limit, retry, circuit-breaker, error-classification (outermost to
+// innermost), so that a request rejected by the rate limiter never counts
+// against the circuit breaker or triggers a retry, and the circuit breaker
+// sees every individual attempt a retry makes rather than just the final
+// outcome of a whole retry sequence.
+func FiltersFromConfig(provider config.Provider, scope tally.Scope, log ulog.Log) []Filter {
+	var cfg FiltersConfig
+	provider.Get("modules.http.client.filters").PopulateStruct(&cfg)
+
+	var filters []Filter
+	if cfg.RateLimit != nil {
+		filters = append(filters, rateLimitFilter(*cfg.RateLimit, scope))
+	}
+	if cfg.Retry != nil {
+		filters = append(filters, retryFilter(*cfg.Retry, scope, log))
+	}
+	if cfg.CircuitBreaker != nil {
+		filters = append(filters, circuitBreakerFilter(*cfg.CircuitBreaker, scope))
+	}
+	filters = append(filters, errorClassificationFilter(scope, log))
+	return filters
+}
+
+// DistributedRateLimitFilterFromConfig builds the baggage-based distributed
+// rate-limit filter described by the `modules.http.client.ratelimit`
+// section of provider, or returns nil if that section is absent. backend
+// may be nil, in which case the limiter enforces its quota purely locally
+// to this process rather than syncing counts fleet-wide.
+func DistributedRateLimitFilterFromConfig(provider config.Provider, backend RateLimitBackend, scope tally.Scope) Filter {
+	value := provider.Get("modules.http.client.ratelimit")
+	if !value.HasValue() {
+		return nil
+	}
+
+	var cfg DistributedRateLimitConfig
+	value.PopulateStruct(&cfg)
+	return distributedRateLimitFilter(cfg, backend, scope)
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/uber-go/tally"
+)
+
+// errDistributedRateLimited is wrapped into the *client.Error the
+// distributed rate-limit filter returns when a caller's quota for a
+// downstream is exhausted.
+var errDistributedRateLimited = errors.New("client: distributed rate limit exceeded")
+
+// DistributedRateLimitConfig configures the baggage-based distributed
+// rate-limit filter, read from `modules.http.client.ratelimit`.
+type DistributedRateLimitConfig struct {
+	// BaggageKey is the OpenTracing baggage item carrying the caller's
+	// identity. Defaults to "x-fx-caller".
+	BaggageKey string `yaml:"baggageKey" default:"x-fx-caller"`
+
+	// Window is the width of the sliding window.
+	Window time.Duration `yaml:"window" default:"1s"`
+
+	// SubBuckets is the number of slices Window is divided into.
+	SubBuckets int `yaml:"subBuckets" default:"10"`
+
+	// DefaultLimit is the maximum number of requests a single caller may
+	// make to a single downstream within Window.
+	DefaultLimit int64 `yaml:"defaultLimit" default:"100"`
+
+	// Routes overrides DefaultLimit for specific downstream services
+	// (matched against the outbound request's URL host).
+	Routes map[string]int64 `yaml:"routes"`
+}
+
+func (cfg DistributedRateLimitConfig) limitFor(downstream string) int64 {
+	if limit, ok := cfg.Routes[downstream]; ok {
+		return limit
+	}
+	return cfg.DefaultLimit
+}
+
+func (cfg DistributedRateLimitConfig) baggageKey() string {
+	if cfg.BaggageKey == "" {
+		return "x-fx-caller"
+	}
+	return cfg.BaggageKey
+}
+
+// RateLimitBackend lets the sliding-window counter be synced across
+// instances, instead of staying purely local to this process. It mirrors
+// the same Window/SubBuckets sliding window the local slidingWindow uses,
+// so a backend only has to store per-sub-bucket counts with a TTL; callers
+// may back it with Redis, an in-memory gRPC coordinator, or anything else
+// that can atomically increment a counter and sum a range of them.
+// MemoryRateLimitBackend, in this package, is a ready-to-use implementation
+// for a single process or tests.
+type RateLimitBackend interface {
+	// IncrementBucket adds 1 to the sub-bucket bucketIdx of key, creating it
+	// with the given TTL if it doesn't exist yet.
+	IncrementBucket(key string, bucketIdx int64, ttl time.Duration) error
+
+	// SumBuckets returns the total count across the subBuckets sub-buckets
+	// of key ending at (and including) bucketIdx, i.e. the same "divide the
+	// window into sub-buckets and sum the live ones" computation the local
+	// slidingWindow performs, but backed by shared storage.
+	SumBuckets(key string, bucketIdx int64, subBuckets int) (int64, error)
+}
+
+// slidingWindow is a local, per-(caller,downstream) sliding-window counter:
+// Window is divided into len(buckets) slices; each request increments the
+// slice for "now", and the filter sums every slice still within Window.
+type slidingWindow struct {
+	mu          sync.Mutex
+	buckets     []int64
+	bucketStart []time.Time
+}
+
+func newSlidingWindow(subBuckets int) *slidingWindow {
+	return &slidingWindow{
+		buckets:     make([]int64, subBuckets),
+		bucketStart: make([]time.Time, subBuckets),
+	}
+}
+
+// incrementAndSum records one request at now and returns the total count
+// across every bucket still within window of now, expiring any bucket
+// that's aged out.
+func (w *slidingWindow) incrementAndSum(now time.Time, window time.Duration) int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	bucketWidth := window / time.Duration(len(w.buckets))
+	slot := now.UnixNano() / int64(bucketWidth)
+	idx := int(slot % int64(len(w.buckets)))
+	slotStart := time.Unix(0, slot*int64(bucketWidth))
+
+	// bucketStart holds the start of the sub-bucket slot a bucket counts;
+	// a bucket last used for an earlier slot has rotated out and restarts.
+	if !w.bucketStart[idx].Equal(slotStart) {
+		w.buckets[idx] = 0
+		w.bucketStart[idx] = slotStart
+	}
+	w.buckets[idx]++
+
+	var sum int64
+	for i, start := range w.bucketStart {
+		if now.Sub(start) < window {
+			sum += w.buckets[i]
+		}
+	}
+	return sum
+}
+
+// distributedRateLimiter tracks one slidingWindow per (callerID,
+// downstream) pair and optionally mirrors counts to a shared backend.
+// callerID comes verbatim from caller-controlled OpenTracing baggage, so
+// counters is swept on every access to evict keys that have gone idle for
+// a full window; otherwise a caller that varies its identity per request
+// would grow counters without bound.
+type distributedRateLimiter struct {
+	cfg     DistributedRateLimitConfig
+	backend RateLimitBackend
+
+	mu       sync.Mutex
+	counters map[string]*slidingWindow
+	lastSeen map[string]time.Time
+}
+
+func newDistributedRateLimiter(cfg DistributedRateLimitConfig, backend RateLimitBackend) *distributedRateLimiter {
+	if cfg.SubBuckets < 1 {
+		cfg.SubBuckets = 1
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = time.Second
+	}
+	return &distributedRateLimiter{
+		cfg:      cfg,
+		backend:  backend,
+		counters: make(map[string]*slidingWindow),
+		lastSeen: make(map[string]time.Time),
+	}
+}
+
+func (rl *distributedRateLimiter) counterFor(key string, now time.Time) *slidingWindow {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.lastSeen[key] = now
+	for k, seen := range rl.lastSeen {
+		if now.Sub(seen) >= rl.cfg.Window {
+			delete(rl.lastSeen, k)
+			delete(rl.counters, k)
+		}
+	}
+
+	w, ok := rl.counters[key]
+	if !ok {
+		w = newSlidingWindow(rl.cfg.SubBuckets)
+		rl.counters[key] = w
+	}
+	return w
+}
+
+// bucketIndex returns the index of the sub-bucket "now" falls into, on the
+// same ever-increasing timeline the local slidingWindow and any
+// RateLimitBackend both key off of, so a backend-backed count lines up with
+// what the local sliding window would have computed for the same instant.
+func (rl *distributedRateLimiter) bucketIndex(now time.Time) int64 {
+	bucketWidth := rl.cfg.Window / time.Duration(rl.cfg.SubBuckets)
+	return now.UnixNano() / int64(bucketWidth)
+}
+
+// allow records one request for (callerID, downstream) and reports whether
+// it is within limit. When a shared backend is configured, its count takes
+// precedence over the local one, so a quota is enforced fleet-wide rather
+// than per-instance; if the backend call fails, allow falls back to the
+// local count so a transient backend outage fails open to per-instance
+// enforcement instead of rejecting every request.
+func (rl *distributedRateLimiter) allow(callerID, downstream string) bool {
+	key := callerID + "|" + downstream
+	limit := rl.cfg.limitFor(downstream)
+	now := time.Now()
+
+	count := rl.counterFor(key, now).incrementAndSum(now, rl.cfg.Window)
+	if rl.backend != nil {
+		idx := rl.bucketIndex(now)
+		if err := rl.backend.IncrementBucket(key, idx, rl.cfg.Window); err == nil {
+			if backendCount, err := rl.backend.SumBuckets(key, idx, rl.cfg.SubBuckets); err == nil {
+				count = backendCount
+			}
+		}
+	}
+	return count <= limit
+}
+
+// retryAfter is the wait hint attached to a rejection: one sub-bucket's
+// width, i.e. roughly how long until the oldest counted request ages out of
+// the window. It's derived from rl.cfg rather than the caller's original
+// config so it always reflects the clamped Window and SubBuckets actually
+// used by the counters.
+func (rl *distributedRateLimiter) retryAfter() time.Duration {
+	return rl.cfg.Window / time.Duration(rl.cfg.SubBuckets)
+}
+
+// distributedRateLimitFilter enforces a per-caller quota on outbound calls
+// to each downstream, keyed by the caller identity carried in the inbound
+// OpenTracing span's baggage.
+func distributedRateLimitFilter(cfg DistributedRateLimitConfig, backend RateLimitBackend, scope tally.Scope) Filter {
+	limiter := newDistributedRateLimiter(cfg, backend)
+	rejected := scope.Counter("http.client.ratelimit.distributed.rejected")
+
+	return FilterFunc(func(ctx fx.Context, req *http.Request, next BasicClient) (*http.Response, error) {
+		span := opentracing.SpanFromContext(ctx)
+		if span == nil {
+			return next.Do(ctx, req)
+		}
+
+		callerID := span.BaggageItem(cfg.baggageKey())
+		if callerID == "" {
+			return next.Do(ctx, req)
+		}
+
+		downstream := req.URL.Host
+		if !limiter.allow(callerID, downstream) {
+			rejected.Inc(1)
+			return nil, newErrorWithRetryAfter(CodeRateLimited, nil, errDistributedRateLimited, limiter.retryAfter())
+		}
+		return next.Do(ctx, req)
+	})
+}
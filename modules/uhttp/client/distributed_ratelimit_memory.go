@@ -0,0 +1,118 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// bucketEntry is one (key, bucketIdx) counter, along with the time it
+// expires so MemoryRateLimitBackend can lazily evict it instead of running
+// a background sweep.
+type bucketEntry struct {
+	count    int64
+	expireAt int64 // UnixNano
+}
+
+// MemoryRateLimitBackend is an in-process RateLimitBackend, suitable for
+// coordinating instances that share a single address space (e.g. under a
+// test harness, or a sidecar process reachable over a local gRPC socket)
+// without standing up Redis. Production deployments that need a
+// fleet-wide quota across separate processes should implement
+// RateLimitBackend against Redis or an equivalent shared store instead.
+//
+// key is caller-controlled (it's derived from OpenTracing baggage), so on
+// top of the per-sub-bucket TTL, IncrementBucket also tracks the most
+// recent expiry seen for each key and sweeps whichever keys have gone
+// wholly idle, so a caller that churns through identities can't grow
+// buckets without bound.
+type MemoryRateLimitBackend struct {
+	mu          sync.Mutex
+	buckets     map[string]map[int64]*bucketEntry
+	keyExpireAt map[string]int64 // UnixNano
+}
+
+// NewMemoryRateLimitBackend returns an empty MemoryRateLimitBackend.
+func NewMemoryRateLimitBackend() *MemoryRateLimitBackend {
+	return &MemoryRateLimitBackend{
+		buckets:     make(map[string]map[int64]*bucketEntry),
+		keyExpireAt: make(map[string]int64),
+	}
+}
+
+// IncrementBucket implements RateLimitBackend.
+func (b *MemoryRateLimitBackend) IncrementBucket(key string, bucketIdx int64, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now().UnixNano()
+
+	perKey, ok := b.buckets[key]
+	if !ok {
+		perKey = make(map[int64]*bucketEntry)
+		b.buckets[key] = perKey
+	}
+	for idx, e := range perKey {
+		if e.expireAt <= now {
+			delete(perKey, idx)
+		}
+	}
+
+	entry, ok := perKey[bucketIdx]
+	if !ok {
+		entry = &bucketEntry{}
+		perKey[bucketIdx] = entry
+	}
+	entry.count++
+	entry.expireAt = now + ttl.Nanoseconds()
+	b.keyExpireAt[key] = entry.expireAt
+
+	for k, exp := range b.keyExpireAt {
+		if exp <= now {
+			delete(b.keyExpireAt, k)
+			delete(b.buckets, k)
+		}
+	}
+	return nil
+}
+
+// SumBuckets implements RateLimitBackend.
+func (b *MemoryRateLimitBackend) SumBuckets(key string, bucketIdx int64, subBuckets int) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	perKey := b.buckets[key]
+	if perKey == nil {
+		return 0, nil
+	}
+
+	now := time.Now().UnixNano()
+	var sum int64
+	for i := int64(0); i < int64(subBuckets); i++ {
+		entry, ok := perKey[bucketIdx-i]
+		if !ok || entry.expireAt <= now {
+			continue
+		}
+		sum += entry.count
+	}
+	return sum, nil
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/fx/internal/fxcontext"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestDistributedRateLimitFilter_RejectsOverLimit(t *testing.T) {
+	withOpentracingSetup(t, nil, func(tracer opentracing.Tracer) {
+		cfg := DistributedRateLimitConfig{Window: time.Second, SubBuckets: 1, DefaultLimit: 1}
+		execChain := newExecutionChain(
+			[]Filter{distributedRateLimitFilter(cfg, nil, tally.NoopScope)},
+			getNopClient(),
+		)
+
+		span := tracer.StartSpan("test_method")
+		span.SetBaggageItem(cfg.baggageKey(), "caller-a")
+		ctx := &fxcontext.Context{
+			Context: opentracing.ContextWithSpan(context.Background(), span),
+		}
+
+		resp, err := execChain.Do(ctx, _req)
+		assert.NoError(t, err)
+		assert.Equal(t, _respOK, resp)
+
+		_, err = execChain.Do(ctx, _req)
+		assert.Equal(t, CodeRateLimited, Code(err))
+		var cerr *Error
+		assert.ErrorAs(t, err, &cerr)
+		assert.Equal(t, cfg.Window, cerr.RetryAfter)
+	})
+}
+
+func TestDistributedRateLimitFilter_PassesThroughWithoutCallerBaggage(t *testing.T) {
+	withOpentracingSetup(t, nil, func(tracer opentracing.Tracer) {
+		cfg := DistributedRateLimitConfig{Window: time.Second, SubBuckets: 1, DefaultLimit: 1}
+		execChain := newExecutionChain(
+			[]Filter{distributedRateLimitFilter(cfg, nil, tally.NoopScope)},
+			getNopClient(),
+		)
+
+		span := tracer.StartSpan("test_method")
+		ctx := &fxcontext.Context{
+			Context: opentracing.ContextWithSpan(context.Background(), span),
+		}
+
+		resp, err := execChain.Do(ctx, _req)
+		assert.NoError(t, err)
+		assert.Equal(t, _respOK, resp)
+
+		resp, err = execChain.Do(ctx, _req)
+		assert.NoError(t, err)
+		assert.Equal(t, _respOK, resp)
+	})
+}
+
+func TestDistributedRateLimitFilter_RejectsOverLimitWithBackend(t *testing.T) {
+	withOpentracingSetup(t, nil, func(tracer opentracing.Tracer) {
+		cfg := DistributedRateLimitConfig{Window: time.Second, SubBuckets: 1, DefaultLimit: 1}
+		backend := NewMemoryRateLimitBackend()
+		execChain := newExecutionChain(
+			[]Filter{distributedRateLimitFilter(cfg, backend, tally.NoopScope)},
+			getNopClient(),
+		)
+
+		span := tracer.StartSpan("test_method")
+		span.SetBaggageItem(cfg.baggageKey(), "caller-a")
+		ctx := &fxcontext.Context{
+			Context: opentracing.ContextWithSpan(context.Background(), span),
+		}
+
+		resp, err := execChain.Do(ctx, _req)
+		assert.NoError(t, err)
+		assert.Equal(t, _respOK, resp)
+
+		_, err = execChain.Do(ctx, _req)
+		assert.Equal(t, CodeRateLimited, Code(err))
+	})
+}
+
+func TestMemoryRateLimitBackend_SumsAcrossBuckets(t *testing.T) {
+	backend := NewMemoryRateLimitBackend()
+
+	require.NoError(t, backend.IncrementBucket("k", 10, time.Minute))
+	require.NoError(t, backend.IncrementBucket("k", 10, time.Minute))
+	require.NoError(t, backend.IncrementBucket("k", 9, time.Minute))
+
+	sum, err := backend.SumBuckets("k", 10, 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), sum)
+
+	sum, err = backend.SumBuckets("k", 8, 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), sum, "buckets outside the requested range must not be counted")
+}
+
+func TestMemoryRateLimitBackend_ExpiresBuckets(t *testing.T) {
+	backend := NewMemoryRateLimitBackend()
+
+	require.NoError(t, backend.IncrementBucket("k", 1, time.Nanosecond))
+	time.Sleep(time.Millisecond)
+
+	sum, err := backend.SumBuckets("k", 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), sum)
+}
+
+func TestMemoryRateLimitBackend_EvictsIdleKeys(t *testing.T) {
+	backend := NewMemoryRateLimitBackend()
+
+	require.NoError(t, backend.IncrementBucket("caller-a", 1, time.Nanosecond))
+	time.Sleep(time.Millisecond)
+	require.NoError(t, backend.IncrementBucket("caller-b", 1, time.Minute))
+
+	assert.NotContains(t, backend.buckets, "caller-a", "an idle key must be swept, not retained forever")
+	assert.Contains(t, backend.buckets, "caller-b")
+}
+
+func TestDistributedRateLimiter_EvictsIdleKeys(t *testing.T) {
+	rl := newDistributedRateLimiter(DistributedRateLimitConfig{Window: time.Millisecond, SubBuckets: 1, DefaultLimit: 100}, nil)
+
+	rl.allow("caller-a", "downstream")
+	time.Sleep(2 * time.Millisecond)
+	rl.allow("caller-b", "downstream")
+
+	assert.NotContains(t, rl.counters, "caller-a|downstream", "an idle caller must be swept, not retained forever")
+	assert.Contains(t, rl.counters, "caller-b|downstream")
+}
+
+func TestDistributedRateLimitConfig_LimitFor(t *testing.T) {
+	cfg := DistributedRateLimitConfig{
+		DefaultLimit: 100,
+		Routes:       map[string]int64{"payments.example.com": 10},
+	}
+	assert.Equal(t, int64(10), cfg.limitFor("payments.example.com"))
+	assert.Equal(t, int64(100), cfg.limitFor("other.example.com"))
+}
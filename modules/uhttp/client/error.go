@@ -0,0 +1,123 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrorCode is a stable, low-cardinality label for an outbound HTTP
+// failure, suitable for tally tags and log fields.
+type ErrorCode string
+
+// The set of classifications the error-classification filter can produce.
+// Filters further down the chain (circuit breaker, rate limit) also use
+// these when they short-circuit a call themselves.
+const (
+	CodeTimeout           ErrorCode = "timeout"
+	CodeCanceled          ErrorCode = "canceled"
+	CodeConnectionRefused ErrorCode = "connection_refused"
+	CodeDNS               ErrorCode = "dns"
+	CodeTLS               ErrorCode = "tls"
+	CodeClientError       ErrorCode = "4xx"
+	CodeServerError       ErrorCode = "5xx"
+	CodeCircuitOpen       ErrorCode = "circuit_open"
+	CodeRateLimited       ErrorCode = "rate_limited"
+	CodeAuthFailure       ErrorCode = "auth_failure"
+	CodeUnknown           ErrorCode = "unknown"
+)
+
+// Error is a classified outbound HTTP failure. It wraps the underlying
+// error so callers can still errors.Is/As through to it, while giving
+// business logic and the retry filter a stable code to branch on.
+type Error struct {
+	// Code classifies the failure for metrics, logs and retry decisions.
+	Code ErrorCode
+
+	// Response is the HTTP response that produced this error, if any (set
+	// for CodeClientError and CodeServerError).
+	Response *http.Response
+
+	// Retryable reports whether the retry filter should attempt this call
+	// again.
+	Retryable bool
+
+	// RetryAfter, if non-zero, is a wait hint from the failing filter (e.g.
+	// the distributed rate limiter). The retry filter uses it in place of its
+	// exponential backoff, still with full jitter applied, so the actual
+	// wait before the next attempt is a random duration in [0, RetryAfter).
+	RetryAfter time.Duration
+
+	// Err is the underlying error this Error classifies.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("client: %s", e.Code)
+	}
+	return fmt.Sprintf("client: %s: %v", e.Code, e.Err)
+}
+
+// Unwrap allows errors.Is and errors.As to see through to the underlying
+// error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newError wraps err with the given classification.
+func newError(code ErrorCode, retryable bool, resp *http.Response, err error) *Error {
+	return &Error{Code: code, Response: resp, Retryable: retryable, Err: err}
+}
+
+// newErrorWithRetryAfter is newError plus a hint for how long the retry
+// filter should wait before its next attempt.
+func newErrorWithRetryAfter(code ErrorCode, resp *http.Response, err error, retryAfter time.Duration) *Error {
+	return &Error{Code: code, Response: resp, Retryable: true, RetryAfter: retryAfter, Err: err}
+}
+
+// IsRetryable reports whether a request that failed with err is safe to
+// retry. A *client.Error reports its own retryability; any other error
+// falls back to a best-effort net.Error check.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var cerr *Error
+	if errors.As(err, &cerr) {
+		return cerr.Retryable
+	}
+	return isRetryableNetError(err)
+}
+
+// Code returns the ErrorCode classifying err, or CodeUnknown if err isn't
+// (or doesn't wrap) a *client.Error.
+func Code(err error) ErrorCode {
+	var cerr *Error
+	if errors.As(err, &cerr) {
+		return cerr.Code
+	}
+	return CodeUnknown
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"go.uber.org/fx"
+	"go.uber.org/fx/ulog"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/tally"
+)
+
+func TestErrorClassificationFilter_ClassifiesTransportError(t *testing.T) {
+	execChain := newExecutionChain(
+		[]Filter{errorClassificationFilter(tally.NoopScope, ulog.NopLogger)},
+		getErrorClient(),
+	)
+	_, err := execChain.Do(fx.NopContext, _req)
+
+	assert.Error(t, err)
+	assert.Equal(t, CodeUnknown, Code(err))
+	assert.False(t, IsRetryable(err))
+}
+
+func TestErrorClassificationFilter_ClassifiesServerError(t *testing.T) {
+	serverErrClient := BasicClientFunc(func(ctx fx.Context, req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusBadGateway}, nil
+	})
+	execChain := newExecutionChain(
+		[]Filter{errorClassificationFilter(tally.NoopScope, ulog.NopLogger)},
+		serverErrClient,
+	)
+	_, err := execChain.Do(fx.NopContext, _req)
+
+	assert.Equal(t, CodeServerError, Code(err))
+	assert.True(t, IsRetryable(err))
+}
+
+func TestErrorClassificationFilter_PassesThroughSuccess(t *testing.T) {
+	execChain := newExecutionChain(
+		[]Filter{errorClassificationFilter(tally.NoopScope, ulog.NopLogger)},
+		getNopClient(),
+	)
+	resp, err := execChain.Do(fx.NopContext, _req)
+	assert.NoError(t, err)
+	assert.Equal(t, _respOK, resp)
+}
+
+func TestCode_UnclassifiedError(t *testing.T) {
+	assert.Equal(t, CodeUnknown, Code(errClient))
+	assert.False(t, IsRetryable(errClient))
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/uber-go/tally"
+)
+
+// errCircuitOpenCause is wrapped into the *client.Error the circuit-breaker
+// filter returns when it short circuits a call without invoking the
+// downstream client.
+var errCircuitOpenCause = errors.New("client: circuit breaker is open")
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// CircuitBreakerConfig configures the built-in circuit-breaker filter.
+type CircuitBreakerConfig struct {
+	// RollingWindow is the width of the sliding window used to compute the
+	// error rate.
+	RollingWindow time.Duration `yaml:"rollingWindow" default:"10s"`
+
+	// RollingBuckets is the number of buckets the rolling window is divided
+	// into. Older buckets are evicted as the window rotates.
+	RollingBuckets int `yaml:"rollingBuckets" default:"10"`
+
+	// ErrorThreshold is the fraction of failed requests, in [0, 1], within
+	// the rolling window that trips the breaker open.
+	ErrorThreshold float64 `yaml:"errorThreshold" default:"0.5"`
+
+	// MinRequests is the minimum number of requests observed in the rolling
+	// window before the error rate is evaluated. Prevents a single failure
+	// from tripping the breaker under low traffic.
+	MinRequests int64 `yaml:"minRequests" default:"20"`
+
+	// OpenTimeout is how long the breaker stays open before allowing a
+	// single half-open probe through.
+	OpenTimeout time.Duration `yaml:"openTimeout" default:"5s"`
+}
+
+// bucket tallies successes and failures for one slice of the rolling window.
+type bucket struct {
+	successes int64
+	failures  int64
+}
+
+// circuitBreaker tracks rolling error rates for a single downstream and
+// decides whether outbound calls should be allowed through.
+type circuitBreaker struct {
+	cfg    CircuitBreakerConfig
+	opened tally.Counter
+
+	mu         sync.Mutex
+	buckets    []bucket
+	current    int
+	lastRotate time.Time
+	state      circuitState
+	openedAt   time.Time
+}
+
+func newCircuitBreaker(cfg CircuitBreakerConfig, opened tally.Counter) *circuitBreaker {
+	if cfg.RollingBuckets < 1 {
+		cfg.RollingBuckets = 1
+	}
+	return &circuitBreaker{
+		cfg:        cfg,
+		opened:     opened,
+		buckets:    make([]bucket, cfg.RollingBuckets),
+		lastRotate: time.Now(),
+	}
+}
+
+func (cb *circuitBreaker) bucketWidth() time.Duration {
+	return cb.cfg.RollingWindow / time.Duration(cb.cfg.RollingBuckets)
+}
+
+// rotate advances the ring buffer to the current time, clearing any buckets
+// that have aged out of the window.
+func (cb *circuitBreaker) rotate(now time.Time) {
+	width := cb.bucketWidth()
+	if width <= 0 {
+		return
+	}
+	elapsed := now.Sub(cb.lastRotate)
+	steps := int(elapsed / width)
+	if steps <= 0 {
+		return
+	}
+	if steps > len(cb.buckets) {
+		steps = len(cb.buckets)
+	}
+	for i := 0; i < steps; i++ {
+		cb.current = (cb.current + 1) % len(cb.buckets)
+		cb.buckets[cb.current] = bucket{}
+	}
+	cb.lastRotate = now
+}
+
+func (cb *circuitBreaker) errorRate() (rate float64, total int64) {
+	var successes, failures int64
+	for _, b := range cb.buckets {
+		successes += b.successes
+		failures += b.failures
+	}
+	total = successes + failures
+	if total == 0 {
+		return 0, 0
+	}
+	return float64(failures) / float64(total), total
+}
+
+// allow reports whether a request should be let through. This also governs
+// the single half-open probe: the first caller after OpenTimeout elapses
+// flips the breaker to half-open and is let through; every other caller is
+// rejected until that probe resolves in recordResult.
+func (cb *circuitBreaker) allow(now time.Time) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.rotate(now)
+
+	switch cb.state {
+	case circuitOpen:
+		if now.Sub(cb.openedAt) < cb.cfg.OpenTimeout {
+			return false
+		}
+		cb.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		// Only one probe is in flight at a time; further callers are
+		// rejected until the probe resolves.
+		return false
+	default:
+		return true
+	}
+}
+
+func (cb *circuitBreaker) recordResult(now time.Time, success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.rotate(now)
+	if success {
+		cb.buckets[cb.current].successes++
+	} else {
+		cb.buckets[cb.current].failures++
+	}
+
+	switch cb.state {
+	case circuitHalfOpen:
+		if success {
+			cb.state = circuitClosed
+			for i := range cb.buckets {
+				cb.buckets[i] = bucket{}
+			}
+		} else {
+			cb.state = circuitOpen
+			cb.openedAt = now
+			cb.opened.Inc(1)
+		}
+	case circuitClosed:
+		rate, total := cb.errorRate()
+		if total >= cb.cfg.MinRequests && rate >= cb.cfg.ErrorThreshold {
+			cb.state = circuitOpen
+			cb.openedAt = now
+			cb.opened.Inc(1)
+		}
+	}
+}
+
+// circuitBreakerFilter rejects outbound calls once the rolling error rate
+// for a downstream crosses cfg.ErrorThreshold, periodically letting a single
+// half-open probe through to test recovery. The http.client.circuit.open
+// counter fires on each closed→open or half-open→open transition, not on
+// every rejected call, so it reflects actual trips rather than the volume
+// of calls shed while open.
+func circuitBreakerFilter(cfg CircuitBreakerConfig, scope tally.Scope) Filter {
+	cb := newCircuitBreaker(cfg, scope.Counter("http.client.circuit.open"))
+
+	return FilterFunc(func(ctx fx.Context, req *http.Request, next BasicClient) (*http.Response, error) {
+		now := time.Now()
+		if !cb.allow(now) {
+			return nil, newError(CodeCircuitOpen, false, nil, errCircuitOpenCause)
+		}
+
+		resp, err := next.Do(ctx, req)
+		cb.recordResult(time.Now(), err == nil)
+		return resp, err
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.uber.org/fx"
+	"go.uber.org/fx/ulog"
+
+	"github.com/uber-go/tally"
+)
+
+// errorClassificationFilter wraps whatever error the inner BasicClient
+// returns into a *client.Error, so every other filter and the caller's
+// business logic can branch on a stable Code instead of string-matching
+// net/url/http errors. It should be the innermost filter in the chain, so
+// it only ever sees errors from the real transport, not ones already
+// classified or synthesized by filters further out (circuit breaker, rate
+// limit).
+func errorClassificationFilter(scope tally.Scope, log ulog.Log) Filter {
+	return FilterFunc(func(ctx fx.Context, req *http.Request, next BasicClient) (*http.Response, error) {
+		resp, err := next.Do(ctx, req)
+		if err == nil {
+			if cerr := classifyResponse(resp); cerr != nil {
+				err = cerr
+			} else {
+				return resp, nil
+			}
+		} else {
+			err = classifyError(err)
+		}
+
+		cerr := err.(*Error)
+		scope.Tagged(map[string]string{"code": string(cerr.Code)}).Counter("http.client.error").Inc(1)
+		log.Error("outbound request failed", "url", req.URL.String(), "code", cerr.Code, "error", cerr.Err)
+		return resp, cerr
+	})
+}
+
+// classifyResponse turns a 4xx/5xx HTTP response into a *client.Error. It
+// returns nil for any response that isn't itself an error.
+func classifyResponse(resp *http.Response) *Error {
+	switch {
+	case resp == nil:
+		return nil
+	case resp.StatusCode >= 500:
+		return newError(CodeServerError, true, resp, nil)
+	case resp.StatusCode >= 400:
+		return newError(CodeClientError, false, resp, nil)
+	default:
+		return nil
+	}
+}
+
+// classifyError inspects a transport-level error and wraps it into a
+// *client.Error with the appropriate code and retryability.
+func classifyError(err error) *Error {
+	switch {
+	case err == context.Canceled:
+		return newError(CodeCanceled, false, nil, err)
+	case err == context.DeadlineExceeded:
+		return newError(CodeTimeout, true, nil, err)
+	}
+
+	if urlErr, ok := err.(*url.Error); ok {
+		if urlErr.Timeout() {
+			return newError(CodeTimeout, true, nil, err)
+		}
+		return classifyError(urlErr.Err)
+	}
+
+	if dnsErr, ok := err.(*net.DNSError); ok {
+		return newError(CodeDNS, !dnsErr.IsNotFound, nil, err)
+	}
+
+	if strings.Contains(err.Error(), "certificate") || strings.Contains(err.Error(), "x509") {
+		return newError(CodeTLS, false, nil, err)
+	}
+
+	if opErr, ok := err.(*net.OpError); ok {
+		if opErr.Timeout() {
+			return newError(CodeTimeout, true, nil, err)
+		}
+		if strings.Contains(opErr.Error(), "connection refused") {
+			return newError(CodeConnectionRefused, true, nil, err)
+		}
+	}
+
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return newError(CodeTimeout, true, nil, err)
+	}
+
+	return newError(CodeUnknown, false, nil, err)
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/uber-go/tally"
+)
+
+// errRateLimitedCause is wrapped into the *client.Error the rate-limit
+// filter returns when a request is rejected because the token bucket is
+// empty.
+var errRateLimitedCause = errors.New("client: rate limit exceeded")
+
+// RateLimitConfig configures the built-in token-bucket rate-limit filter.
+type RateLimitConfig struct {
+	// RequestsPerSecond is the steady-state rate at which tokens are
+	// added to the bucket.
+	RequestsPerSecond float64 `yaml:"requestsPerSecond" default:"100"`
+
+	// Burst is the maximum number of tokens the bucket can hold, i.e. the
+	// largest burst of requests allowed above the steady-state rate.
+	Burst int64 `yaml:"burst" default:"100"`
+}
+
+// tokenBucket is a minimal, mutex-protected token bucket.
+type tokenBucket struct {
+	mu         sync.Mutex
+	rate       float64
+	burst      float64
+	tokens     float64
+	lastRefill time.Time
+}
+
+func newTokenBucket(cfg RateLimitConfig) *tokenBucket {
+	burst := float64(cfg.Burst)
+	if burst <= 0 {
+		burst = cfg.RequestsPerSecond
+	}
+	return &tokenBucket{
+		rate:       cfg.RequestsPerSecond,
+		burst:      burst,
+		tokens:     burst,
+		lastRefill: time.Now(),
+	}
+}
+
+// take attempts to remove a single token, refilling based on elapsed time.
+// It reports whether the token was available.
+func (b *tokenBucket) take(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.tokens += elapsed * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.lastRefill = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// rateLimitFilter rejects outbound calls once the local token bucket for a
+// downstream is exhausted.
+func rateLimitFilter(cfg RateLimitConfig, scope tally.Scope) Filter {
+	bucket := newTokenBucket(cfg)
+	rejected := scope.Counter("http.client.ratelimit.rejected")
+
+	return FilterFunc(func(ctx fx.Context, req *http.Request, next BasicClient) (*http.Response, error) {
+		if !bucket.take(time.Now()) {
+			rejected.Inc(1)
+			return nil, newError(CodeRateLimited, true, nil, errRateLimitedCause)
+		}
+		return next.Do(ctx, req)
+	})
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/fx/ulog"
+
+	"github.com/uber-go/tally"
+)
+
+// RetryConfig configures the built-in retry filter.
+type RetryConfig struct {
+	// MaxAttempts is the total number of times a request is attempted,
+	// including the first. Defaults to 1 (no retries) if unset.
+	MaxAttempts int `yaml:"maxAttempts" default:"1"`
+
+	// PerAttemptTimeout bounds each individual attempt. Zero means no
+	// per-attempt timeout is applied beyond the caller's context deadline.
+	PerAttemptTimeout time.Duration `yaml:"perAttemptTimeout"`
+
+	// BaseBackoff is the starting point for exponential backoff between
+	// attempts, before jitter is applied.
+	BaseBackoff time.Duration `yaml:"baseBackoff" default:"50ms"`
+
+	// MaxBackoff caps the computed backoff, regardless of attempt count.
+	MaxBackoff time.Duration `yaml:"maxBackoff" default:"2s"`
+}
+
+// retryFilter retries requests that fail with a retryable error, using
+// exponential backoff with full jitter between attempts.
+func retryFilter(cfg RetryConfig, scope tally.Scope, log ulog.Log) Filter {
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	counter := scope.Counter("http.client.retry")
+
+	return FilterFunc(func(ctx fx.Context, req *http.Request, next BasicClient) (*http.Response, error) {
+		var resp *http.Response
+		var err error
+
+		for attempt := 0; attempt < cfg.MaxAttempts; attempt++ {
+			if attempt > 0 {
+				counter.Inc(1)
+				if sleepErr := sleepWithJitter(ctx, nextBackoff(err, attempt, cfg.BaseBackoff, cfg.MaxBackoff)); sleepErr != nil {
+					return nil, sleepErr
+				}
+				log.Debug("retrying outbound request", "attempt", attempt, "url", req.URL.String())
+			}
+
+			attemptCtx, cancel := withPerAttemptTimeout(ctx, cfg.PerAttemptTimeout)
+			resp, err = next.Do(attemptCtx, req)
+			cancel()
+
+			if err == nil || !IsRetryable(err) {
+				return resp, err
+			}
+		}
+		return resp, err
+	})
+}
+
+// nextBackoff picks the wait before the next attempt: a failing filter's own
+// RetryAfter hint (e.g. from the distributed rate limiter) takes precedence
+// over the usual exponential backoff. sleepWithJitter then applies full
+// jitter to whichever value is picked.
+func nextBackoff(err error, attempt int, base, max time.Duration) time.Duration {
+	var cerr *Error
+	if errors.As(err, &cerr) && cerr.RetryAfter > 0 {
+		return cerr.RetryAfter
+	}
+	return backoff(attempt, base, max)
+}
+
+// backoff computes the exponential backoff duration for the given attempt
+// (1-indexed), capped at max.
+func backoff(attempt int, base, max time.Duration) time.Duration {
+	d := base << uint(attempt-1)
+	if d <= 0 || d > max {
+		d = max
+	}
+	return d
+}
+
+// sleepWithJitter sleeps for a random duration in [0, d) (full jitter),
+// honoring ctx cancellation.
+func sleepWithJitter(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	jittered := time.Duration(rand.Int63n(int64(d)))
+	timer := time.NewTimer(jittered)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// timeoutContext wraps an fx.Context, overriding the context.Context methods
+// (deadline, cancellation and Value) with inner, which is derived from the
+// wrapped context, so the rest of the value (Host, baggage, etc.) is
+// preserved.
+type timeoutContext struct {
+	fx.Context
+	inner context.Context
+}
+
+func (t timeoutContext) Deadline() (time.Time, bool)       { return t.inner.Deadline() }
+func (t timeoutContext) Done() <-chan struct{}             { return t.inner.Done() }
+func (t timeoutContext) Err() error                        { return t.inner.Err() }
+func (t timeoutContext) Value(key interface{}) interface{} { return t.inner.Value(key) }
+
+// withPerAttemptTimeout derives a child context bounded by timeout, if set.
+func withPerAttemptTimeout(ctx fx.Context, timeout time.Duration) (fx.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	inner, cancel := context.WithTimeout(ctx, timeout)
+	return timeoutContext{Context: ctx, inner: inner}, cancel
+}
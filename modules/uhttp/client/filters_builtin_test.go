@@ -0,0 +1,148 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/fx/ulog"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/tally"
+)
+
+// fakeTimeoutError implements net.Error to exercise the retry filter's
+// retryability check without depending on a real network stack.
+type fakeTimeoutError struct{}
+
+func (fakeTimeoutError) Error() string   { return "fake timeout" }
+func (fakeTimeoutError) Timeout() bool   { return true }
+func (fakeTimeoutError) Temporary() bool { return true }
+
+func TestRetryFilter_SucceedsAfterTransientFailure(t *testing.T) {
+	attempts := 0
+	flaky := BasicClientFunc(func(ctx fx.Context, req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, fakeTimeoutError{}
+		}
+		return _respOK, nil
+	})
+
+	execChain := newExecutionChain(
+		[]Filter{retryFilter(RetryConfig{MaxAttempts: 3, BaseBackoff: time.Millisecond}, tally.NoopScope, ulog.NopLogger)},
+		flaky,
+	)
+	resp, err := execChain.Do(fx.NopContext, _req)
+	assert.NoError(t, err)
+	assert.Equal(t, _respOK, resp)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetryFilter_DoesNotRetryNonRetryableError(t *testing.T) {
+	attempts := 0
+	client := BasicClientFunc(func(ctx fx.Context, req *http.Request) (*http.Response, error) {
+		attempts++
+		return nil, errClient
+	})
+
+	execChain := newExecutionChain(
+		[]Filter{retryFilter(RetryConfig{MaxAttempts: 3, BaseBackoff: time.Millisecond}, tally.NoopScope, ulog.NopLogger)},
+		client,
+	)
+	_, err := execChain.Do(fx.NopContext, _req)
+	assert.Equal(t, errClient, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestCircuitBreakerFilter_OpensAfterThreshold(t *testing.T) {
+	cfg := CircuitBreakerConfig{
+		RollingWindow:  time.Second,
+		RollingBuckets: 10,
+		ErrorThreshold: 0.5,
+		MinRequests:    2,
+		OpenTimeout:    time.Minute,
+	}
+	execChain := newExecutionChain(
+		[]Filter{circuitBreakerFilter(cfg, tally.NoopScope)},
+		getErrorClient(),
+	)
+
+	for i := 0; i < 2; i++ {
+		_, err := execChain.Do(fx.NopContext, _req)
+		assert.Equal(t, errClient, err)
+	}
+
+	_, err := execChain.Do(fx.NopContext, _req)
+	assert.Equal(t, CodeCircuitOpen, Code(err))
+}
+
+func TestCircuitBreakerFilter_EmitsOpenMetricOnlyOnTrip(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	cfg := CircuitBreakerConfig{
+		RollingWindow:  time.Second,
+		RollingBuckets: 10,
+		ErrorThreshold: 0.5,
+		MinRequests:    2,
+		OpenTimeout:    time.Minute,
+	}
+	execChain := newExecutionChain(
+		[]Filter{circuitBreakerFilter(cfg, scope)},
+		getErrorClient(),
+	)
+
+	for i := 0; i < 2; i++ {
+		_, err := execChain.Do(fx.NopContext, _req)
+		assert.Equal(t, errClient, err)
+	}
+	assert.Equal(t, int64(1), openCircuitCount(scope), "metric should fire on the closed->open trip itself")
+
+	_, err := execChain.Do(fx.NopContext, _req)
+	assert.Equal(t, CodeCircuitOpen, Code(err))
+	assert.Equal(t, int64(1), openCircuitCount(scope), "rejecting calls while already open must not re-fire the metric")
+}
+
+func openCircuitCount(scope tally.TestScope) int64 {
+	for _, c := range scope.Snapshot().Counters() {
+		if c.Name() == "http.client.circuit.open" {
+			return c.Value()
+		}
+	}
+	return 0
+}
+
+func TestRateLimitFilter_RejectsOverBurst(t *testing.T) {
+	cfg := RateLimitConfig{RequestsPerSecond: 1, Burst: 1}
+	execChain := newExecutionChain(
+		[]Filter{rateLimitFilter(cfg, tally.NoopScope)},
+		getNopClient(),
+	)
+
+	resp, err := execChain.Do(fx.NopContext, _req)
+	assert.NoError(t, err)
+	assert.Equal(t, _respOK, resp)
+
+	_, err = execChain.Do(fx.NopContext, _req)
+	assert.Equal(t, CodeRateLimited, Code(err))
+}